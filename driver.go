@@ -0,0 +1,61 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Driver is the contract a concrete message broker backend must satisfy to
+// be selectable via config.PubSubConfig.Driver. PubSub (the Google Cloud
+// implementation) already satisfies it, so existing callers keep working
+// unchanged while new backends (in-memory, Kafka, NATS, ...) can be added
+// without touching port.PubSubReceiver code.
+type Driver interface {
+	Install(args ...any) error
+	Connect() error
+	Disconnect() error
+	Uninstall() error
+
+	Publish(ctx context.Context, message any, attributes map[string]string, opts ...PublishOptions) (string, error)
+	RegisterReceiver(receiver port.PubSubReceiver)
+	StartReceiving(ctx context.Context)
+
+	EnsureTopicExists(ctx context.Context) bool
+	EnsureSubscriptionExists(ctx context.Context) bool
+}
+
+// DriverFactory builds a Driver from config. Drivers register a factory
+// under their name via RegisterDriver, typically from their own init().
+type DriverFactory func(ctx context.Context, config config.PubSubConfig) (Driver, error)
+
+var driverRegistry = map[string]DriverFactory{}
+
+// RegisterDriver makes a driver factory available under name for
+// config.PubSubConfig.Driver to select. It panics on duplicate registration
+// since that indicates two drivers claiming the same name, not a runtime
+// condition callers can recover from.
+func RegisterDriver(name string, factory DriverFactory) {
+	if _, exists := driverRegistry[name]; exists {
+		panic(fmt.Sprintf("pubsub: driver %q already registered", name))
+	}
+	driverRegistry[name] = factory
+}
+
+// NewDriver resolves config.Driver to a registered factory and builds it.
+// An empty Driver defaults to "gcp" to preserve existing behavior.
+func NewDriver(ctx context.Context, config config.PubSubConfig) (Driver, error) {
+	name := config.Driver
+	if name == "" {
+		name = "gcp"
+	}
+
+	factory, ok := driverRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("pubsub: unknown driver %q", name)
+	}
+
+	return factory(ctx, config)
+}
@@ -0,0 +1,14 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+func init() {
+	RegisterDriver("kafka", func(ctx context.Context, config config.PubSubConfig) (Driver, error) {
+		return nil, fmt.Errorf("pubsub: kafka driver is not implemented yet")
+	})
+}
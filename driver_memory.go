@@ -0,0 +1,138 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+func init() {
+	RegisterDriver("memory", func(ctx context.Context, config config.PubSubConfig) (Driver, error) {
+		return NewMemoryDriver(config), nil
+	})
+}
+
+// MemoryDriver is an in-process Driver with no network dependency. It lets
+// services exercise their PubSubReceiver wiring in local development and CI
+// without GCP credentials: published messages are delivered to registered
+// receivers synchronously, in-process.
+type MemoryDriver struct {
+	config      config.PubSubConfig
+	mu          sync.Mutex
+	receivers   []port.PubSubReceiver
+	seq         int
+	Marshaler   Marshaler
+	Unmarshaler Unmarshaler
+}
+
+// NewMemoryDriver creates a new in-process Driver.
+func NewMemoryDriver(config config.PubSubConfig) *MemoryDriver {
+	return &MemoryDriver{
+		config:      config,
+		Marshaler:   DefaultMarshaler{},
+		Unmarshaler: DefaultMarshaler{},
+	}
+}
+
+func (m *MemoryDriver) Install(args ...any) error {
+	return nil
+}
+
+func (m *MemoryDriver) Connect() error {
+	return nil
+}
+
+func (m *MemoryDriver) Disconnect() error {
+	return nil
+}
+
+func (m *MemoryDriver) Uninstall() error {
+	return nil
+}
+
+// SetMarshaler implements marshalerSetter so PubSubLoader.Init can wire a
+// custom Marshaler into this driver from its variadic args.
+func (m *MemoryDriver) SetMarshaler(marshaler Marshaler) { m.Marshaler = marshaler }
+
+// SetUnmarshaler implements marshalerSetter so PubSubLoader.Init can wire a
+// custom Unmarshaler into this driver from its variadic args.
+func (m *MemoryDriver) SetUnmarshaler(unmarshaler Unmarshaler) { m.Unmarshaler = unmarshaler }
+
+func (m *MemoryDriver) RegisterReceiver(receiver port.PubSubReceiver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.receivers = append(m.receivers, receiver)
+}
+
+// StartReceiving is a no-op: delivery happens synchronously inside Publish.
+func (m *MemoryDriver) StartReceiving(ctx context.Context) {
+}
+
+// Publish runs message through m.Marshaler/m.Unmarshaler just like the gcp
+// driver does across the wire, so a receiver registered against MemoryDriver
+// sees the same AttributeMessageUUID and metadata handling it would see in
+// production instead of a simplified in-process shortcut.
+func (m *MemoryDriver) Publish(ctx context.Context, message any, attributes map[string]string, opts ...PublishOptions) (string, error) {
+	str, ok := message.(string)
+	if !ok {
+		var err error
+		str, err = helper.ToJSON(message)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	data, publishAttributes, err := m.Marshaler.Marshal(&Message{Payload: []byte(str), Metadata: attributes})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	m.mu.Lock()
+	m.seq++
+	id := fmt.Sprintf("memory-%s-%d", m.config.Topic, m.seq)
+	receivers := append([]port.PubSubReceiver(nil), m.receivers...)
+	m.mu.Unlock()
+
+	if len(receivers) == 0 {
+		logger.Error("MemoryDriver has no Receiver to process incomming message")
+		return id, nil
+	}
+
+	receiveAttributes := publishAttributes
+	if domainMsg, err := m.Unmarshaler.Unmarshal(data, publishAttributes); err == nil {
+		receiveAttributes = domainMsg.Metadata
+	}
+	if receiveAttributes == nil {
+		receiveAttributes = map[string]string{}
+	}
+	receiveAttributes[AttributeGoogleMessageID] = id
+
+	msg := &PubSubMessage{
+		ID:          id,
+		Data:        data,
+		PublishTime: time.Now(),
+		Attributes:  receiveAttributes,
+	}
+
+	for _, c := range receivers {
+		if _, err := c.Consume(ctx, []port.IPubSubMessage{msg}); err != nil {
+			logger.Debug("MemoryDriver: receiver failed to consume message", "error", err)
+		}
+	}
+
+	return id, nil
+}
+
+func (m *MemoryDriver) EnsureTopicExists(ctx context.Context) bool {
+	return true
+}
+
+func (m *MemoryDriver) EnsureSubscriptionExists(ctx context.Context) bool {
+	return true
+}
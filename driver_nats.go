@@ -0,0 +1,14 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+func init() {
+	RegisterDriver("nats", func(ctx context.Context, config config.PubSubConfig) (Driver, error) {
+		return nil, fmt.Errorf("pubsub: nats driver is not implemented yet")
+	})
+}
@@ -3,8 +3,10 @@ package pubsub
 import (
 	"context"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/webcore-go/webcore/infra/config"
 	"github.com/webcore-go/webcore/port"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PubSubLoader struct {
@@ -19,19 +21,45 @@ func (a *PubSubLoader) Name() string {
 	return a.name
 }
 
+// marshalerSetter is implemented by any Driver backend exposing
+// configurable Marshaler/Unmarshaler fields (currently *PubSub,
+// *MemoryDriver, and *PubSubLite), letting Init wire a custom pair into
+// whichever backend NewDriver produced instead of only *PubSub.
+type marshalerSetter interface {
+	SetMarshaler(Marshaler)
+	SetUnmarshaler(Unmarshaler)
+}
+
 func (l *PubSubLoader) Init(args ...any) (port.Library, error) {
 	context := args[0].(context.Context)
 	config := args[1].(config.PubSubConfig)
 
-	pubsub, err := NewPubSub(context, config)
+	driver, err := NewDriver(context, config)
 	if err != nil {
 		return nil, err
 	}
 
-	err = pubsub.Install(args...)
+	for _, arg := range args[2:] {
+		if setter, ok := driver.(marshalerSetter); ok {
+			if marshaler, ok := arg.(Marshaler); ok {
+				setter.SetMarshaler(marshaler)
+			}
+			if unmarshaler, ok := arg.(Unmarshaler); ok {
+				setter.SetUnmarshaler(unmarshaler)
+			}
+		}
+		if registerer, ok := arg.(prometheus.Registerer); ok {
+			WithMetrics(registerer)
+		}
+		if tracerProvider, ok := arg.(trace.TracerProvider); ok {
+			WithTracer(tracerProvider)
+		}
+	}
+
+	err = driver.Install(args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return pubsub, nil
+	return driver, nil
 }
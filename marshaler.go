@@ -0,0 +1,81 @@
+package pubsub
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	// AttributeMessageUUID is the attribute key carrying the message's
+	// stable, idempotent UUID. Publish generates one automatically when the
+	// caller doesn't already set it.
+	AttributeMessageUUID = "_webcore_message_uuid"
+
+	// AttributeGoogleMessageID is populated on receive from the underlying
+	// transport's msg.ID, which changes on every redelivery and therefore
+	// cannot be used for idempotency on its own.
+	AttributeGoogleMessageID = "_webcore_google_message_id"
+
+	// AttributeLitePartition is populated on receive by PubSubLite with the
+	// Lite partition the message was delivered from, so receivers relying on
+	// per-partition ordering can tell when their partition assignment
+	// changes instead of only seeing it logged.
+	AttributeLitePartition = "_webcore_lite_partition"
+)
+
+// Message is the broker-agnostic envelope a Marshaler/Unmarshaler pair
+// converts to and from PubSub wire data and attributes, in the style of
+// Watermill's message abstraction.
+type Message struct {
+	UUID     string
+	Payload  []byte
+	Metadata map[string]string
+}
+
+// Marshaler maps a Message to the Data/Attributes pair PublishMessage sends
+// over the wire. Implementations can layer in custom wire formats (e.g.
+// protobuf, Avro, CloudEvents) while still getting the reserved attribute
+// handling for free by embedding DefaultMarshaler.
+type Marshaler interface {
+	Marshal(msg *Message) (data []byte, attributes map[string]string, err error)
+}
+
+// Unmarshaler maps received Data/Attributes back to a Message.
+type Unmarshaler interface {
+	Unmarshal(data []byte, attributes map[string]string) (*Message, error)
+}
+
+// DefaultMarshaler passes Payload and Metadata through unchanged, only
+// ensuring AttributeMessageUUID is present so receivers get a stable,
+// idempotent ID even across redelivery.
+type DefaultMarshaler struct{}
+
+func (DefaultMarshaler) Marshal(msg *Message) ([]byte, map[string]string, error) {
+	attributes := make(map[string]string, len(msg.Metadata)+1)
+	for k, v := range msg.Metadata {
+		attributes[k] = v
+	}
+
+	id := msg.UUID
+	if id == "" {
+		id = attributes[AttributeMessageUUID]
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	attributes[AttributeMessageUUID] = id
+
+	return msg.Payload, attributes, nil
+}
+
+func (DefaultMarshaler) Unmarshal(data []byte, attributes map[string]string) (*Message, error) {
+	metadata := make(map[string]string, len(attributes))
+	for k, v := range attributes {
+		metadata[k] = v
+	}
+
+	return &Message{
+		UUID:     attributes[AttributeMessageUUID],
+		Payload:  data,
+		Metadata: metadata,
+	}, nil
+}
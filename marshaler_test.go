@@ -0,0 +1,32 @@
+package pubsub
+
+import "testing"
+
+func TestDefaultMarshalerKeepsPreSetUUIDAttribute(t *testing.T) {
+	want := "caller-supplied-uuid"
+	data, attributes, err := DefaultMarshaler{}.Marshal(&Message{
+		Payload:  []byte("payload"),
+		Metadata: map[string]string{AttributeMessageUUID: want},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := attributes[AttributeMessageUUID]; got != want {
+		t.Fatalf("attributes[AttributeMessageUUID] = %q, want %q (a retried publish must keep its idempotency key)", got, want)
+	}
+	if string(data) != "payload" {
+		t.Fatalf("data = %q, want %q", data, "payload")
+	}
+}
+
+func TestDefaultMarshalerGeneratesUUIDWhenAbsent(t *testing.T) {
+	_, attributes, err := DefaultMarshaler{}.Marshal(&Message{Payload: []byte("payload")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if attributes[AttributeMessageUUID] == "" {
+		t.Fatalf("expected a generated AttributeMessageUUID when none was supplied")
+	}
+}
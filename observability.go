@@ -0,0 +1,138 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/webcore-go/webcore/port"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	publishTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pubsub_publish_total",
+		Help: "Total number of PubSub publish attempts, labeled by topic and result.",
+	}, []string{"topic", "result"})
+
+	publishLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "pubsub_publish_latency_seconds",
+		Help: "Latency of PubSub publish calls, labeled by topic.",
+	}, []string{"topic"})
+
+	receiveTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pubsub_receive_total",
+		Help: "Total number of PubSub messages received, labeled by subscription and ack/nack result.",
+	}, []string{"subscription", "result"})
+
+	receiveLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "pubsub_receive_latency_seconds",
+		Help: "Latency of PubSub receive handling, from dispatch to receivers to ack/nack, labeled by subscription.",
+	}, []string{"subscription"})
+)
+
+// There is intentionally no ack-deadline-extension counter here: the
+// cloud.google.com/go/pubsub client library only exposes a bound on
+// auto-extension (ReceiveSettings.MaxExtensionPeriod, set from
+// Config.AckDeadlineExtension in StartReceiving), not a callback fired on
+// each extension, so there's no real signal to count against. receiveLatency
+// is the closest available proxy for "this message needed its deadline
+// extended": a receive latency approaching MaxExtensionPeriod implies the
+// library extended at least once to keep the message outstanding.
+
+// WithMetrics registers the package's Prometheus collectors on registerer.
+// Pass its result through PubSubLoader.Init's variadic args to enable it.
+// Registering the same collector twice (e.g. Init called more than once
+// against the same registerer) is tolerated rather than panicking, since
+// AlreadyRegisteredError just means a previous Init already did the work.
+func WithMetrics(registerer prometheus.Registerer) prometheus.Registerer {
+	for _, collector := range []prometheus.Collector{publishTotal, publishLatency, receiveTotal, receiveLatency} {
+		if err := registerer.Register(collector); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				panic(err)
+			}
+		}
+	}
+	return registerer
+}
+
+var tracerProvider trace.TracerProvider = otel.GetTracerProvider()
+
+// WithTracer sets the TracerProvider used to start publish/receive spans.
+// Pass its result through PubSubLoader.Init's variadic args to enable it.
+func WithTracer(tp trace.TracerProvider) trace.TracerProvider {
+	tracerProvider = tp
+	return tp
+}
+
+func tracer() trace.Tracer {
+	return tracerProvider.Tracer("github.com/webcore-go/lib-pubsub")
+}
+
+// messageCarrier adapts a message's attributes map to
+// propagation.TextMapCarrier so a W3C traceparent can be injected into
+// outgoing attributes and extracted from incoming ones, linking
+// publisher and subscriber traces across services.
+type messageCarrier map[string]string
+
+func (c messageCarrier) Get(key string) string { return c[key] }
+
+func (c messageCarrier) Set(key, value string) { c[key] = value }
+
+func (c messageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ReceiverMiddleware wraps a port.PubSubReceiver, letting callers layer
+// retry, idempotency, and logging behavior onto RegisterReceiver without
+// touching StartReceiving.
+type ReceiverMiddleware func(next port.PubSubReceiver) port.PubSubReceiver
+
+// ReceiverFunc adapts a Consume function to port.PubSubReceiver, so
+// middleware can wrap a receiver without declaring a named type.
+type ReceiverFunc func(ctx context.Context, messages []port.IPubSubMessage) (map[string]bool, error)
+
+func (f ReceiverFunc) Consume(ctx context.Context, messages []port.IPubSubMessage) (map[string]bool, error) {
+	return f(ctx, messages)
+}
+
+func startPublishSpan(ctx context.Context, topic string, attributes map[string]string) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, "pubsub.Publish", trace.WithAttributes(
+		attribute.String("messaging.destination", topic),
+	))
+	otel.GetTextMapPropagator().Inject(ctx, messageCarrier(attributes))
+	return ctx, span
+}
+
+func startReceiveSpan(ctx context.Context, subscription, messageID string, attributes map[string]string) trace.Span {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, messageCarrier(attributes))
+	_, span := tracer().Start(ctx, "pubsub.Receive", trace.WithAttributes(
+		attribute.String("messaging.source", subscription),
+		attribute.String("messaging.message_id", messageID),
+	))
+	return span
+}
+
+func observePublish(topic string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	publishTotal.WithLabelValues(topic, result).Inc()
+	publishLatency.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+}
+
+func observeReceive(subscription string, start time.Time, acked bool) {
+	result := "nack"
+	if acked {
+		result = "ack"
+	}
+	receiveTotal.WithLabelValues(subscription, result).Inc()
+	receiveLatency.WithLabelValues(subscription).Observe(time.Since(start).Seconds())
+}
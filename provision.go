@@ -0,0 +1,142 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/webcore-go/webcore/infra/logger"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// TopicSpec declaratively describes a topic Provision should ensure exists.
+type TopicSpec struct {
+	Name             string
+	Labels           map[string]string
+	MessageRetention time.Duration
+	SchemaSettings   *pubsubpb.SchemaSettings
+}
+
+// DeadLetterPolicy configures dead-lettering for a SubscriptionSpec.
+type DeadLetterPolicy struct {
+	Topic               string
+	MaxDeliveryAttempts int32
+}
+
+// SubscriptionSpec declaratively describes a subscription Provision should
+// ensure exists, bound to Topic.
+type SubscriptionSpec struct {
+	Name                      string
+	Topic                     string
+	AckDeadline               time.Duration
+	RetryPolicy               *pubsubpb.RetryPolicy
+	DeadLetterPolicy          *DeadLetterPolicy
+	Filter                    string
+	EnableExactlyOnceDelivery bool
+	EnableMessageOrdering     bool
+	ExpirationPolicy          *time.Duration
+}
+
+// ProvisionSpec is the declarative schema of topics and subscriptions
+// Provision should ensure exist. It is a pubsub-package type rather than a
+// config.PubSubConfig field so config doesn't need to import pubsub: pass it
+// through PubSubLoader.Init's variadic args instead, the same way Marshaler,
+// Unmarshaler, and the observability options are wired in.
+type ProvisionSpec struct {
+	Topics        []TopicSpec
+	Subscriptions []SubscriptionSpec
+}
+
+// Provision reconciles spec's topics and subscriptions against the project,
+// creating whatever is missing. It mirrors the pubsubtool provisioning
+// pattern so environments no longer need manual gcloud setup.
+func (ps *PubSub) Provision(ctx context.Context, spec ProvisionSpec) error {
+	for _, topic := range spec.Topics {
+		if err := ps.provisionTopic(ctx, topic); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range spec.Subscriptions {
+		if err := ps.provisionSubscription(ctx, sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ps *PubSub) provisionTopic(ctx context.Context, spec TopicSpec) error {
+	name := fmt.Sprintf("projects/%s/topics/%s", ps.Config.ProjectID, spec.Name)
+
+	req := &pubsubpb.ListTopicsRequest{Project: fmt.Sprintf("projects/%s", ps.Config.ProjectID)}
+	it := ps.Client.TopicAdminClient.ListTopics(ctx, req)
+	for {
+		topic, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list topics while provisioning %s: %v", spec.Name, err)
+		}
+		if topic.Name == name {
+			return nil
+		}
+	}
+
+	_, err := ps.Client.TopicAdminClient.CreateTopic(ctx, &pubsubpb.Topic{
+		Name:                     name,
+		Labels:                   spec.Labels,
+		MessageRetentionDuration: durationpb.New(spec.MessageRetention),
+		SchemaSettings:           spec.SchemaSettings,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create topic %s: %v", spec.Name, err)
+	}
+
+	logger.Info("Provisioned PubSub topic", "topic", name)
+	return nil
+}
+
+func (ps *PubSub) provisionSubscription(ctx context.Context, spec SubscriptionSpec) error {
+	name := fmt.Sprintf("projects/%s/subscriptions/%s", ps.Config.ProjectID, spec.Name)
+	topic := fmt.Sprintf("projects/%s/topics/%s", ps.Config.ProjectID, spec.Topic)
+
+	for _, sub := range ps.ListSubscriptions(ctx) {
+		if sub != nil && sub.Name == name {
+			return nil
+		}
+	}
+
+	sub := &pubsubpb.Subscription{
+		Name:                      name,
+		Topic:                     topic,
+		AckDeadlineSeconds:        int32(spec.AckDeadline.Seconds()),
+		RetryPolicy:               spec.RetryPolicy,
+		Filter:                    spec.Filter,
+		EnableExactlyOnceDelivery: spec.EnableExactlyOnceDelivery,
+		EnableMessageOrdering:     spec.EnableMessageOrdering,
+	}
+
+	if spec.DeadLetterPolicy != nil {
+		sub.DeadLetterPolicy = &pubsubpb.DeadLetterPolicy{
+			DeadLetterTopic:     fmt.Sprintf("projects/%s/topics/%s", ps.Config.ProjectID, spec.DeadLetterPolicy.Topic),
+			MaxDeliveryAttempts: spec.DeadLetterPolicy.MaxDeliveryAttempts,
+		}
+	}
+
+	if spec.ExpirationPolicy != nil {
+		sub.ExpirationPolicy = &pubsubpb.ExpirationPolicy{
+			Ttl: durationpb.New(*spec.ExpirationPolicy),
+		}
+	}
+
+	if _, err := ps.Client.SubscriptionAdminClient.CreateSubscription(ctx, sub); err != nil {
+		return fmt.Errorf("failed to create subscription %s: %v", spec.Name, err)
+	}
+
+	logger.Info("Provisioned PubSub subscription", "subscription", name)
+	return nil
+}
@@ -5,8 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/pubsub/v2"
@@ -15,6 +15,7 @@ import (
 	"github.com/webcore-go/webcore/infra/config"
 	"github.com/webcore-go/webcore/infra/logger"
 	"github.com/webcore-go/webcore/port"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
@@ -45,99 +46,108 @@ func (p *PubSubMessage) GetAttributes() map[string]string {
 
 // PubSub represents shared Google PubSub connection
 type PubSub struct {
-	Client    *pubsub.Client
-	Config    config.PubSubConfig
-	Receivers []port.PubSubReceiver
+	Client      *pubsub.Client
+	Config      config.PubSubConfig
+	Receivers   []port.PubSubReceiver
+	Marshaler   Marshaler
+	Unmarshaler Unmarshaler
+	Middlewares []ReceiverMiddleware
+}
+
+func init() {
+	RegisterDriver("gcp", func(ctx context.Context, config config.PubSubConfig) (Driver, error) {
+		return NewPubSub(ctx, config)
+	})
 }
 
 // NewPubSub creates a new PubSub connection
 func NewPubSub(ctx context.Context, config config.PubSubConfig) (*PubSub, error) {
-	var client *pubsub.Client
-	var err error
-
 	if config.ProjectID == "" {
 		return nil, fmt.Errorf("PubSub config project_id cannot be empty")
 	}
 
-	// Configure PubSub client options
-	opts := []option.ClientOption{}
-
-	// if config.EmulatorHost != "" {
-	// 	opts = append(opts, option.WithEndpoint(config.EmulatorHost), option.WithoutAuthentication())
-	// }
+	opts, err := clientOptions(config)
+	if err != nil {
+		return nil, err
+	}
 
-	// Handle credentials: if file path is not available but config.Credentials is provided,
-	// create a temporary credentials file
-	if config.CredentialsPath != "" {
-		// Check if the credentials file exists
-		if _, err := os.Stat(config.CredentialsPath); os.IsNotExist(err) {
-			// File doesn't exist, check if we have credentials data in config
-			if config.Credentials != nil {
-				config.Credentials.ProjectID = config.ProjectID
-				config.Credentials.PrivateKey = strings.ReplaceAll(config.Credentials.PrivateKey, "\\n", "\n")
-				// Create the directory if it doesn't exist
-				dir := filepath.Dir(config.CredentialsPath)
-				if err := os.MkdirAll(dir, 0755); err != nil {
-					return nil, fmt.Errorf("failed to create credentials directory: %v", err)
-				}
+	client, err := pubsub.NewClient(ctx, config.ProjectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PubSub client: %v", err)
+	}
 
-				// Marshal the credentials to JSON
-				credJSON, err := json.MarshalIndent(config.Credentials, "", "  ")
-				if err != nil {
-					return nil, fmt.Errorf("failed to marshal credentials: %v", err)
-				}
+	return &PubSub{
+		Client:      client,
+		Config:      config,
+		Receivers:   []port.PubSubReceiver{},
+		Marshaler:   DefaultMarshaler{},
+		Unmarshaler: DefaultMarshaler{},
+	}, nil
+}
 
-				// Write the credentials to the file
-				if err := os.WriteFile(config.CredentialsPath, credJSON, 0600); err != nil {
-					return nil, fmt.Errorf("failed to write credentials file: %v", err)
-				}
+// clientOptions resolves credentials in priority order: explicit in-memory
+// credentials JSON built from config.Credentials, an explicit
+// config.CredentialsPath, the GOOGLE_APPLICATION_CREDENTIALS env var, and
+// finally Application Default Credentials / GKE workload identity when
+// nothing is configured. Unlike the previous implementation, service
+// account private keys are never written to a temp file, which was a real
+// security concern in shared build environments. PUBSUB_EMULATOR_HOST (or
+// config.EmulatorHost) takes priority over all of the above for local
+// testing against the Pub/Sub emulator. Shared with NewPubSubLite so both
+// backends resolve credentials identically.
+func clientOptions(config config.PubSubConfig) ([]option.ClientOption, error) {
+	emulatorHost := config.EmulatorHost
+	if emulatorHost == "" {
+		emulatorHost = os.Getenv("PUBSUB_EMULATOR_HOST")
+	}
+	if emulatorHost != "" {
+		logger.Info("Dialing PubSub emulator", "host", emulatorHost)
+		return []option.ClientOption{
+			option.WithEndpoint(emulatorHost),
+			option.WithoutAuthentication(),
+		}, nil
+	}
 
-				logger.Info("Created credentials file from config", "path", config.CredentialsPath)
-			} else {
-				return nil, fmt.Errorf("credentials file not found at %s and no credentials data provided in config", config.CredentialsPath)
-			}
-		}
-		opts = append(opts, option.WithCredentialsFile(config.CredentialsPath))
-	} else if config.Credentials != nil {
+	if config.Credentials != nil {
 		config.Credentials.ProjectID = config.ProjectID
 		config.Credentials.PrivateKey = strings.ReplaceAll(config.Credentials.PrivateKey, "\\n", "\n")
 
-		// No credentials path provided, but we have credentials data
-		// Create a temporary file for the credentials
-		tempDir := os.TempDir()
-		tempCredPath := filepath.Join(tempDir, fmt.Sprintf("pubsub-credentials-%s.json", config.ProjectID))
-
-		// Marshal the credentials to JSON
-		credJSON, err := json.MarshalIndent(config.Credentials, "", "  ")
+		credJSON, err := json.Marshal(config.Credentials)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal credentials: %v", err)
 		}
 
-		// Write the credentials to the temporary file
-		if err := os.WriteFile(tempCredPath, credJSON, 0600); err != nil {
-			return nil, fmt.Errorf("failed to write temporary credentials file: %v", err)
-		}
+		return []option.ClientOption{option.WithCredentialsJSON(credJSON)}, nil
+	}
 
-		logger.Info("Created temporary credentials file from config", "path", tempCredPath)
-		opts = append(opts, option.WithCredentialsFile(tempCredPath))
-	} else {
-		return nil, fmt.Errorf("no credentials provided: either credentials_path or credentials_data must be specified")
+	if config.CredentialsPath != "" {
+		return []option.ClientOption{option.WithCredentialsFile(config.CredentialsPath)}, nil
 	}
 
-	client, err = pubsub.NewClient(ctx, config.ProjectID, opts...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create PubSub client: %v", err)
+	if envPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); envPath != "" {
+		return []option.ClientOption{option.WithCredentialsFile(envPath)}, nil
 	}
 
-	return &PubSub{
-		Client:    client,
-		Config:    config,
-		Receivers: []port.PubSubReceiver{},
-	}, nil
+	// Nothing explicit configured: fall back to Application Default
+	// Credentials, which also covers GKE workload identity.
+	logger.Info("No explicit PubSub credentials configured, falling back to Application Default Credentials")
+	return nil, nil
 }
 
+// Install provisions topics and subscriptions declared by a ProvisionSpec
+// passed through PubSubLoader.Init's variadic args, if any. Without one it
+// does nothing, since most callers provision out of band via gcloud or
+// Terraform.
 func (ps *PubSub) Install(args ...any) error {
-	// Tidak melakukan apa-apa
+	for _, arg := range args {
+		if spec, ok := arg.(ProvisionSpec); ok {
+			ctx, _ := args[0].(context.Context)
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return ps.Provision(ctx, spec)
+		}
+	}
 	return nil
 }
 
@@ -159,7 +169,15 @@ func (ps *PubSub) Uninstall() error {
 	return nil
 }
 
-func (ps *PubSub) Publish(ctx context.Context, message any, attributes map[string]string) (string, error) {
+// PublishOptions customizes a single Publish/PublishMessage/PublishMessages
+// call.
+type PublishOptions struct {
+	// OrderingKey, when set, is attached to the message and enables message
+	// ordering on the underlying Publisher for this topic.
+	OrderingKey string
+}
+
+func (ps *PubSub) Publish(ctx context.Context, message any, attributes map[string]string, opts ...PublishOptions) (string, error) {
 	var str string
 	var ok bool
 	var err error
@@ -172,48 +190,113 @@ func (ps *PubSub) Publish(ctx context.Context, message any, attributes map[strin
 		}
 	}
 
-	return ps.PublishMessage(ctx, []byte(str), attributes)
+	return ps.PublishMessage(ctx, []byte(str), attributes, opts...)
+}
+
+// SetMarshaler implements marshalerSetter so PubSubLoader.Init can wire a
+// custom Marshaler into this driver from its variadic args.
+func (ps *PubSub) SetMarshaler(m Marshaler) { ps.Marshaler = m }
+
+// SetUnmarshaler implements marshalerSetter so PubSubLoader.Init can wire a
+// custom Unmarshaler into this driver from its variadic args.
+func (ps *PubSub) SetUnmarshaler(u Unmarshaler) { ps.Unmarshaler = u }
+
+// Use appends a ReceiverMiddleware applied, innermost first, to every
+// receiver registered afterwards via RegisterReceiver.
+func (ps *PubSub) Use(middleware ReceiverMiddleware) {
+	ps.Middlewares = append(ps.Middlewares, middleware)
 }
 
 func (ps *PubSub) RegisterReceiver(receiver port.PubSubReceiver) {
+	for i := len(ps.Middlewares) - 1; i >= 0; i-- {
+		receiver = ps.Middlewares[i](receiver)
+	}
 	ps.Receivers = append(ps.Receivers, receiver)
 }
 
+// StartReceiving subscribes to ps.Config.Subscription and dispatches
+// messages to registered receivers in batches of up to MaxBatchSize,
+// flushing early every MaxBatchLatency so low-traffic subscriptions don't
+// stall waiting to fill a batch. A message is only Ack'd once a receiver's
+// Consume call reports it succeeded; anything else is Nack'd so Pub/Sub
+// redelivers it with backoff instead of being acknowledged away.
 func (ps *PubSub) StartReceiving(ctx context.Context) {
 	if len(ps.Receivers) == 0 {
 		logger.Error("PubSub has no Receiver to process incomming message")
 		return
 	}
 
+	maxBatchSize := ps.Config.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = 1
+	}
+	maxBatchLatency := ps.Config.MaxBatchLatency
+	if maxBatchLatency <= 0 {
+		maxBatchLatency = time.Second
+	}
+
 	go func() {
 		sub := ps.Client.Subscriber(ps.Config.Subscription)
-		err := sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
-			msg.Ack()
-			m := &PubSubMessage{
-				ID:          msg.ID,
-				Data:        msg.Data,
-				PublishTime: msg.PublishTime,
-				Attributes:  msg.Attributes,
+		if ps.Config.MaxOutstandingMessages > 0 {
+			sub.ReceiveSettings.MaxOutstandingMessages = ps.Config.MaxOutstandingMessages
+		}
+		if ps.Config.MaxOutstandingBytes > 0 {
+			sub.ReceiveSettings.MaxOutstandingBytes = ps.Config.MaxOutstandingBytes
+		}
+		if ps.Config.NumGoroutines > 0 {
+			sub.ReceiveSettings.NumGoroutines = ps.Config.NumGoroutines
+		}
+		// AckDeadlineExtension only configures how long the client library is
+		// allowed to keep auto-extending a message's ack deadline while it's
+		// in flight; the library doesn't expose a per-extension callback, so
+		// there's no signal here to count extensions against, only to bound
+		// them.
+		if ps.Config.AckDeadlineExtension > 0 {
+			sub.ReceiveSettings.MaxExtensionPeriod = ps.Config.AckDeadlineExtension
+		}
+
+		var mu sync.Mutex
+		batch := make([]*pubsub.Message, 0, maxBatchSize)
+
+		flush := func() {
+			mu.Lock()
+			pending := batch
+			batch = make([]*pubsub.Message, 0, maxBatchSize)
+			mu.Unlock()
+
+			if len(pending) > 0 {
+				ps.consumeBatch(ctx, pending)
 			}
+		}
 
-			ackDone := false
-			for _, c := range ps.Receivers {
-				ack, err := c.Consume(ctx, []port.IPubSubMessage{m})
-				if !ackDone && err == nil && len(ack) > 0 {
-					if val, ok := ack[m.ID]; ok && val {
-						ackDone = true
-						msg.Ack()
-						logger.Debug("Message processed and acknowledged", "messageID", msg.ID)
-					}
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(maxBatchLatency)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					flush()
+				case <-done:
+					return
 				}
 			}
+		}()
 
-			if !ackDone {
-				msg.Nack()
-				logger.Debug("Message not processed and not acknowledged", "messageID", msg.ID)
+		err := sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+			mu.Lock()
+			batch = append(batch, msg)
+			full := len(batch) >= maxBatchSize
+			mu.Unlock()
+
+			if full {
+				flush()
 			}
 		})
 
+		flush()
+
 		if err != nil {
 			logger.Error("Error receiving messages", "error", err)
 			return
@@ -221,14 +304,110 @@ func (ps *PubSub) StartReceiving(ctx context.Context) {
 	}()
 }
 
-// EnsureTopicExists checks if the topic exists
+// consumeBatch hands a batch of messages to every registered receiver and
+// Acks or Nacks each message individually based on whether any receiver
+// reported it as successfully consumed.
+func (ps *PubSub) consumeBatch(ctx context.Context, batch []*pubsub.Message) {
+	messages := make([]port.IPubSubMessage, 0, len(batch))
+	byID := make(map[string]*pubsub.Message, len(batch))
+	spans := make(map[string]trace.Span, len(batch))
+	starts := make(map[string]time.Time, len(batch))
+
+	for _, msg := range batch {
+		attributes := msg.Attributes
+		if domainMsg, err := ps.Unmarshaler.Unmarshal(msg.Data, msg.Attributes); err == nil {
+			attributes = domainMsg.Metadata
+		}
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+		attributes[AttributeGoogleMessageID] = msg.ID
+
+		starts[msg.ID] = time.Now()
+		spans[msg.ID] = startReceiveSpan(ctx, ps.Config.Subscription, msg.ID, attributes)
+
+		m := &PubSubMessage{
+			ID:          msg.ID,
+			Data:        msg.Data,
+			PublishTime: msg.PublishTime,
+			Attributes:  attributes,
+		}
+		messages = append(messages, m)
+		byID[msg.ID] = msg
+	}
+
+	acked := resolveAcks(ctx, ps.Receivers, messages)
+
+	for id, msg := range byID {
+		if acked[id] {
+			msg.Ack()
+			logger.Debug("Message processed and acknowledged", "messageID", id)
+		} else {
+			msg.Nack()
+			logger.Debug("Message not processed and not acknowledged", "messageID", id)
+		}
+
+		observeReceive(ps.Config.Subscription, starts[id], acked[id])
+		spans[id].End()
+	}
+}
+
+// resolveAcks runs every receiver's Consume over messages and returns which
+// message IDs at least one receiver reported as successfully consumed. A
+// receiver that errors or omits an ID leaves that message un-acked so
+// consumeBatch Nacks it instead of acking away a message nothing processed.
+func resolveAcks(ctx context.Context, receivers []port.PubSubReceiver, messages []port.IPubSubMessage) map[string]bool {
+	acked := make(map[string]bool, len(messages))
+	for _, c := range receivers {
+		ack, err := c.Consume(ctx, messages)
+		if err != nil {
+			continue
+		}
+		for id, ok := range ack {
+			if ok {
+				acked[id] = true
+			}
+		}
+	}
+	return acked
+}
+
+// EnsureTopicExists checks if the topic exists, creating it if it doesn't.
 func (ps *PubSub) EnsureTopicExists(ctx context.Context) bool {
-	return ps.GetTopicInfo(ctx) != nil
+	if ps.GetTopicInfo(ctx) != nil {
+		return true
+	}
+
+	name := fmt.Sprintf("projects/%s/topics/%s", ps.Config.ProjectID, ps.Config.Topic)
+	if _, err := ps.Client.TopicAdminClient.CreateTopic(ctx, &pubsubpb.Topic{Name: name}); err != nil {
+		logger.Error("Failed to create missing topic", "topic", name, "error", err)
+		return false
+	}
+
+	logger.Info("Created missing PubSub topic", "topic", name)
+	return true
 }
 
-// EnsureSubscriptionExists checks if the subscription exists
+// EnsureSubscriptionExists checks if the subscription exists, creating it
+// bound to ps.Config.Topic if it doesn't.
 func (ps *PubSub) EnsureSubscriptionExists(ctx context.Context) bool {
-	return ps.GetSubscriptionInfo(ctx) != nil
+	if ps.GetSubscriptionInfo(ctx) != nil {
+		return true
+	}
+
+	name := fmt.Sprintf("projects/%s/subscriptions/%s", ps.Config.ProjectID, ps.Config.Subscription)
+	topic := fmt.Sprintf("projects/%s/topics/%s", ps.Config.ProjectID, ps.Config.Topic)
+	_, err := ps.Client.SubscriptionAdminClient.CreateSubscription(ctx, &pubsubpb.Subscription{
+		Name:  name,
+		Topic: topic,
+	})
+	if err != nil {
+		logger.Error("Failed to create missing subscription", "subscription", name, "error", err)
+		return false
+	}
+
+	logger.Info("Created missing PubSub subscription", "subscription", name)
+	return true
 }
 
 // GetTopicInfo returns information about the topic
@@ -294,18 +473,81 @@ func (ps *PubSub) ListSubscriptions(ctx context.Context) []*pubsubpb.Subscriptio
 	return subs
 }
 
-// PublishMessage publishes a message to the topic
-func (ps *PubSub) PublishMessage(ctx context.Context, data []byte, attributes map[string]string) (string, error) {
+// publisher returns a Publisher for ps.Config.Topic configured from
+// ps.Config.PublishSettings (byte/count/delay thresholds, timeout, flow
+// control) and with message ordering enabled globally when
+// ps.Config.MessageOrdering is set.
+func (ps *PubSub) publisher() *pubsub.Publisher {
 	publisher := ps.Client.Publisher(ps.Config.Topic)
+
+	settings := ps.Config.PublishSettings
+	if settings.ByteThreshold > 0 {
+		publisher.PublishSettings.ByteThreshold = settings.ByteThreshold
+	}
+	if settings.CountThreshold > 0 {
+		publisher.PublishSettings.CountThreshold = settings.CountThreshold
+	}
+	if settings.DelayThreshold > 0 {
+		publisher.PublishSettings.DelayThreshold = settings.DelayThreshold
+	}
+	if settings.Timeout > 0 {
+		publisher.PublishSettings.Timeout = settings.Timeout
+	}
+	if settings.FlowControl.MaxOutstandingMessages > 0 {
+		publisher.PublishSettings.FlowControlSettings.MaxOutstandingMessages = settings.FlowControl.MaxOutstandingMessages
+	}
+	if settings.FlowControl.MaxOutstandingBytes > 0 {
+		publisher.PublishSettings.FlowControlSettings.MaxOutstandingBytes = settings.FlowControl.MaxOutstandingBytes
+	}
+
+	if ps.Config.MessageOrdering {
+		publisher.EnableMessageOrdering = true
+	}
+
+	return publisher
+}
+
+// PublishMessage publishes a message to the topic, running it through
+// ps.Marshaler first so every published message carries a stable
+// AttributeMessageUUID even if the caller didn't set one. Passing an
+// OrderingKey via opts enables message ordering for this publish and, on
+// failure, automatically resumes publishing for that key so ordering isn't
+// left permanently stalled.
+func (ps *PubSub) PublishMessage(ctx context.Context, data []byte, attributes map[string]string, opts ...PublishOptions) (string, error) {
+	data, attributes, err := ps.Marshaler.Marshal(&Message{Payload: data, Metadata: attributes})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	start := time.Now()
+	ctx, span := startPublishSpan(ctx, ps.Config.Topic, attributes)
+	defer span.End()
+
+	var orderingKey string
+	if len(opts) > 0 {
+		orderingKey = opts[0].OrderingKey
+	}
+
+	publisher := ps.publisher()
+	if orderingKey != "" {
+		publisher.EnableMessageOrdering = true
+	}
+
 	result := publisher.Publish(ctx, &pubsub.Message{
-		Data:       data,
-		Attributes: attributes,
+		Data:        data,
+		Attributes:  attributes,
+		OrderingKey: orderingKey,
 	})
 
 	// Block until the result is returned and a server-generated
 	// ID is returned for the published message.
 	msgID, err := result.Get(ctx)
+	observePublish(ps.Config.Topic, start, err)
 	if err != nil {
+		if orderingKey != "" {
+			publisher.ResumePublish(orderingKey)
+		}
+		span.RecordError(err)
 		return "", fmt.Errorf("failed to publish message: %v", err)
 	}
 
@@ -313,22 +555,116 @@ func (ps *PubSub) PublishMessage(ctx context.Context, data []byte, attributes ma
 	return msgID, nil
 }
 
-// PublishMessages publishes multiple messages to the topic
-func (ps *PubSub) PublishMessages(ctx context.Context, messages [][]byte, attributes map[string]string) ([]string, error) {
-	results := []string{}
+// maxPublishMessagesConcurrency bounds how many in-flight PublishMessages
+// results are awaited concurrently.
+const maxPublishMessagesConcurrency = 32
+
+// PublishMessages publishes multiple messages to the topic using the async
+// Publish API so the client library can batch the underlying RPCs, then
+// collects each server-generated message ID concurrently (bounded by
+// maxPublishMessagesConcurrency), preserving input order in the returned
+// slice. If opts sets an OrderingKey, a publish failure automatically
+// resumes publishing for that key instead of stalling it permanently.
+func (ps *PubSub) PublishMessages(ctx context.Context, messages [][]byte, attributes map[string]string, opts ...PublishOptions) ([]string, error) {
+	var orderingKey string
+	if len(opts) > 0 {
+		orderingKey = opts[0].OrderingKey
+	}
 
-	var err error
-	var msgID string
-	i := 0
-	for _, msg := range messages {
-		msgID, err = ps.PublishMessage(ctx, msg, attributes)
-		if msgID != "" {
-			results[i] = msgID
-			i++
+	publisher := ps.publisher()
+	if orderingKey != "" {
+		publisher.EnableMessageOrdering = true
+	}
+
+	results := make([]*pubsub.PublishResult, len(messages))
+	spans := make([]trace.Span, len(messages))
+	starts := make([]time.Time, len(messages))
+	for i, msg := range messages {
+		data, msgAttributes, err := ps.Marshaler.Marshal(&Message{Payload: msg, Metadata: attributes})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal message %d: %v", i, err)
+		}
+
+		starts[i] = time.Now()
+		ctx, span := startPublishSpan(ctx, ps.Config.Topic, msgAttributes)
+		spans[i] = span
+
+		results[i] = publisher.Publish(ctx, &pubsub.Message{
+			Data:        data,
+			Attributes:  msgAttributes,
+			OrderingKey: orderingKey,
+		})
+	}
+
+	getters := make([]publishResultGetter, len(results))
+	for i, result := range results {
+		getters[i] = result
+	}
+
+	ids, failedIndex, err := gatherPublishResults(ctx, getters, maxPublishMessagesConcurrency, func(i int, msgID string, err error) {
+		observePublish(ps.Config.Topic, starts[i], err)
+		if err != nil {
+			spans[i].RecordError(err)
+			if orderingKey != "" {
+				publisher.ResumePublish(orderingKey)
+			}
+		}
+		spans[i].End()
+	})
+	if err != nil {
+		return ids, fmt.Errorf("failed to publish message %d: %v", failedIndex, err)
+	}
+
+	return ids, nil
+}
+
+// publishResultGetter abstracts anything that blocks until a publish
+// outcome is known, the same shape as *pubsub.PublishResult.Get, so
+// gatherPublishResults can be exercised by a test without a live Pub/Sub
+// client.
+type publishResultGetter interface {
+	Get(ctx context.Context) (string, error)
+}
+
+// gatherPublishResults awaits up to maxConcurrency results concurrently,
+// preserving their input order in the returned ids slice regardless of
+// which result resolves first, and invokes onResult for each one as it
+// resolves so callers can record metrics/tracing or resume ordered
+// publishing on failure. It reports the index of the first failure so the
+// caller can produce a precise error message.
+func gatherPublishResults(ctx context.Context, results []publishResultGetter, maxConcurrency int, onResult func(i int, msgID string, err error)) (ids []string, failedIndex int, err error) {
+	ids = make([]string, len(results))
+	errs := make([]error, len(results))
+
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for i, result := range results {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, result publishResultGetter) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			msgID, getErr := result.Get(ctx)
+			if onResult != nil {
+				onResult(i, msgID, getErr)
+			}
+			if getErr != nil {
+				errs[i] = getErr
+				return
+			}
+			ids[i] = msgID
+		}(i, result)
+	}
+	wg.Wait()
+
+	for i, e := range errs {
+		if e != nil {
+			return ids, i, e
 		}
 	}
 
-	return results, err
+	return ids, -1, nil
 }
 
 // PullMessages pulls messages from the subscription
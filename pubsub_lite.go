@@ -0,0 +1,288 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/pubsub/v2"
+	"cloud.google.com/go/pubsublite/pscompat"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+func init() {
+	RegisterDriver("gcp-lite", func(ctx context.Context, config config.PubSubConfig) (Driver, error) {
+		return NewPubSubLite(ctx, config)
+	})
+}
+
+// PubSubLite represents a connection to a Cloud Pub/Sub Lite topic and
+// subscription. Unlike PubSub, throughput is partitioned and zonal with
+// predefined reserved capacity rather than autoscaled.
+type PubSubLite struct {
+	Publisher   *pubsub.Publisher
+	Subscriber  *pubsub.Subscriber
+	Config      config.PubSubConfig
+	Receivers   []port.PubSubReceiver
+	Marshaler   Marshaler
+	Unmarshaler Unmarshaler
+}
+
+func liteTopicPath(config config.PubSubConfig) string {
+	return fmt.Sprintf("projects/%s/locations/%s/topics/%s", config.ProjectID, config.Zone, config.Topic)
+}
+
+func liteSubscriptionPath(config config.PubSubConfig) string {
+	return fmt.Sprintf("projects/%s/locations/%s/subscriptions/%s", config.ProjectID, config.Zone, config.Subscription)
+}
+
+// NewPubSubLite creates a new Pub/Sub Lite connection for partitioned,
+// zonal messaging with reserved capacity. Credentials are resolved by the
+// same clientOptions precedence PubSub uses (emulator, in-memory JSON,
+// credentials file, GOOGLE_APPLICATION_CREDENTIALS, ADC), so Lite can be
+// pointed at the Pub/Sub emulator for local testing the same way PubSub can.
+func NewPubSubLite(ctx context.Context, config config.PubSubConfig) (*PubSubLite, error) {
+	if config.ProjectID == "" {
+		return nil, fmt.Errorf("PubSub Lite config project_id cannot be empty")
+	}
+	if config.Zone == "" {
+		return nil, fmt.Errorf("PubSub Lite config zone cannot be empty")
+	}
+	if config.Reservation != "" && config.Region == "" {
+		return nil, fmt.Errorf("PubSub Lite config region cannot be empty when reservation is set")
+	}
+	if config.Reservation != "" {
+		logger.Info("PubSub Lite topic is backed by a capacity reservation", "region", config.Region, "reservation", config.Reservation)
+	}
+
+	opts, err := clientOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	publishSettings := pscompat.PublishSettings{}
+	settings := config.PublishSettings
+	if settings.ByteThreshold > 0 {
+		publishSettings.ByteThreshold = settings.ByteThreshold
+	}
+	if settings.CountThreshold > 0 {
+		publishSettings.CountThreshold = settings.CountThreshold
+	}
+	if settings.DelayThreshold > 0 {
+		publishSettings.DelayThreshold = settings.DelayThreshold
+	}
+	if settings.Timeout > 0 {
+		publishSettings.Timeout = settings.Timeout
+	}
+	if settings.FlowControl.MaxOutstandingMessages > 0 {
+		publishSettings.FlowControlSettings.MaxOutstandingMessages = settings.FlowControl.MaxOutstandingMessages
+	}
+	if settings.FlowControl.MaxOutstandingBytes > 0 {
+		publishSettings.FlowControlSettings.MaxOutstandingBytes = settings.FlowControl.MaxOutstandingBytes
+	}
+	if config.MessageOrdering {
+		publishSettings.EnableMessageOrdering = true
+	}
+
+	publisher, err := pscompat.NewPublisherClientWithSettings(ctx, liteTopicPath(config), publishSettings, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PubSub Lite publisher: %v", err)
+	}
+
+	return &PubSubLite{
+		Publisher:   publisher,
+		Config:      config,
+		Receivers:   []port.PubSubReceiver{},
+		Marshaler:   DefaultMarshaler{},
+		Unmarshaler: DefaultMarshaler{},
+	}, nil
+}
+
+func (pl *PubSubLite) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (pl *PubSubLite) Connect() error {
+	// Tidak melakukan apa-apa proses konek hanya dilakukan saat di mode consumer pull message atau publish message di mode producer
+	return nil
+}
+
+// Disconnect closes the Pub/Sub Lite publisher and subscriber clients.
+func (pl *PubSubLite) Disconnect() error {
+	if pl.Publisher != nil {
+		pl.Publisher.Stop()
+	}
+	if pl.Subscriber != nil {
+		pl.Subscriber.Stop()
+	}
+	return nil
+}
+
+func (pl *PubSubLite) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (pl *PubSubLite) Publish(ctx context.Context, message any, attributes map[string]string, opts ...PublishOptions) (string, error) {
+	var str string
+	var ok bool
+	var err error
+
+	str, ok = message.(string)
+	if !ok {
+		str, err = helper.ToJSON(message)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return pl.PublishMessage(ctx, []byte(str), attributes, opts...)
+}
+
+// PublishMessage publishes a message to the Pub/Sub Lite topic, running it
+// through pl.Marshaler first so every published message carries a stable
+// AttributeMessageUUID, the same as PubSub.PublishMessage. An OrderingKey in
+// opts determines which partition the message lands on.
+func (pl *PubSubLite) PublishMessage(ctx context.Context, data []byte, attributes map[string]string, opts ...PublishOptions) (string, error) {
+	data, attributes, err := pl.Marshaler.Marshal(&Message{Payload: data, Metadata: attributes})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	var orderingKey string
+	if len(opts) > 0 {
+		orderingKey = opts[0].OrderingKey
+	}
+
+	result := pl.Publisher.Publish(ctx, &pubsub.Message{
+		Data:        data,
+		Attributes:  attributes,
+		OrderingKey: orderingKey,
+	})
+
+	msgID, err := result.Get(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to publish message to PubSub Lite: %v", err)
+	}
+
+	logger.Debug("PubSub Lite Publish: message", "msgID", msgID)
+	return msgID, nil
+}
+
+// SetMarshaler implements marshalerSetter so PubSubLoader.Init can wire a
+// custom Marshaler into this driver from its variadic args.
+func (pl *PubSubLite) SetMarshaler(marshaler Marshaler) { pl.Marshaler = marshaler }
+
+// SetUnmarshaler implements marshalerSetter so PubSubLoader.Init can wire a
+// custom Unmarshaler into this driver from its variadic args.
+func (pl *PubSubLite) SetUnmarshaler(unmarshaler Unmarshaler) { pl.Unmarshaler = unmarshaler }
+
+func (pl *PubSubLite) RegisterReceiver(receiver port.PubSubReceiver) {
+	pl.Receivers = append(pl.Receivers, receiver)
+}
+
+// StartReceiving subscribes to the Lite subscription and dispatches
+// messages to registered receivers, preserving per-partition ordering when
+// config.MessageOrdering is enabled (Lite already delivers a single
+// partition's messages in order to one subscriber goroutine). Only
+// MaxOutstandingMessages and MaxOutstandingBytes from pl.Config are applied
+// to the Lite receive settings, and messages are handed to receivers one at
+// a time rather than in batches. The partition a message was delivered from
+// is surfaced to receivers via AttributeLitePartition, not just logged, so
+// code relying on per-partition ordering can detect a reassignment itself.
+func (pl *PubSubLite) StartReceiving(ctx context.Context) {
+	if len(pl.Receivers) == 0 {
+		logger.Error("PubSubLite has no Receiver to process incomming message")
+		return
+	}
+
+	receiveSettings := pscompat.ReceiveSettings{}
+	if pl.Config.MaxOutstandingMessages > 0 {
+		receiveSettings.MaxOutstandingMessages = pl.Config.MaxOutstandingMessages
+	}
+	if pl.Config.MaxOutstandingBytes > 0 {
+		receiveSettings.MaxOutstandingBytes = pl.Config.MaxOutstandingBytes
+	}
+
+	opts, err := clientOptions(pl.Config)
+	if err != nil {
+		logger.Error("Error resolving PubSub Lite credentials", "error", err)
+		return
+	}
+
+	subscriber, err := pscompat.NewSubscriberClientWithSettings(ctx, liteSubscriptionPath(pl.Config), receiveSettings, opts...)
+	if err != nil {
+		logger.Error("Error creating PubSub Lite subscriber", "error", err)
+		return
+	}
+	pl.Subscriber = subscriber
+
+	go func() {
+		lastPartition := int64(-1)
+
+		err := subscriber.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+			partition := lastPartition
+			if metadata, err := pscompat.ParseMessageMetadata(msg.ID); err == nil {
+				partition = int64(metadata.Partition)
+				if partition != lastPartition {
+					lastPartition = partition
+					logger.Info("PubSub Lite partition assignment changed", "partition", lastPartition)
+				}
+			}
+
+			attributes := msg.Attributes
+			if domainMsg, err := pl.Unmarshaler.Unmarshal(msg.Data, msg.Attributes); err == nil {
+				attributes = domainMsg.Metadata
+			}
+			if attributes == nil {
+				attributes = map[string]string{}
+			}
+			attributes[AttributeGoogleMessageID] = msg.ID
+			attributes[AttributeLitePartition] = fmt.Sprintf("%d", partition)
+
+			m := &PubSubMessage{
+				ID:          msg.ID,
+				Data:        msg.Data,
+				PublishTime: msg.PublishTime,
+				Attributes:  attributes,
+			}
+
+			ackDone := false
+			for _, c := range pl.Receivers {
+				ack, err := c.Consume(ctx, []port.IPubSubMessage{m})
+				if !ackDone && err == nil && len(ack) > 0 {
+					if val, ok := ack[m.ID]; ok && val {
+						ackDone = true
+						msg.Ack()
+						logger.Debug("PubSub Lite message processed and acknowledged", "messageID", msg.ID)
+					}
+				}
+			}
+
+			if !ackDone {
+				msg.Nack()
+				logger.Debug("PubSub Lite message not processed and not acknowledged", "messageID", msg.ID)
+			}
+		})
+
+		if err != nil {
+			logger.Error("Error receiving PubSub Lite messages", "error", err)
+		}
+	}()
+}
+
+// EnsureTopicExists is not implemented for Lite: topics are zonal resources
+// provisioned with a Reservation and are expected to be created ahead of
+// time via gcloud or Terraform.
+func (pl *PubSubLite) EnsureTopicExists(ctx context.Context) bool {
+	return true
+}
+
+// EnsureSubscriptionExists mirrors EnsureTopicExists: Lite subscriptions are
+// provisioned out of band.
+func (pl *PubSubLite) EnsureSubscriptionExists(ctx context.Context) bool {
+	return true
+}
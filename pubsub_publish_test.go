@@ -0,0 +1,65 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakePublishResult struct {
+	id  string
+	err error
+}
+
+func (f fakePublishResult) Get(ctx context.Context) (string, error) {
+	return f.id, f.err
+}
+
+func TestGatherPublishResultsPreservesOrderForMultipleMessages(t *testing.T) {
+	results := []publishResultGetter{
+		fakePublishResult{id: "a"},
+		fakePublishResult{id: "b"},
+		fakePublishResult{id: "c"},
+	}
+
+	ids, failedIndex, err := gatherPublishResults(context.Background(), results, 2, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if failedIndex != -1 {
+		t.Fatalf("expected failedIndex -1, got %d", failedIndex)
+	}
+
+	want := []string{"a", "b", "c"}
+	if len(ids) != len(want) {
+		t.Fatalf("got %d ids, want %d (this is the shape of the bug a zero-length results slice would panic on)", len(ids), len(want))
+	}
+	for i, id := range ids {
+		if id != want[i] {
+			t.Fatalf("ids[%d] = %q, want %q", i, id, want[i])
+		}
+	}
+}
+
+func TestGatherPublishResultsReportsFirstFailure(t *testing.T) {
+	var onResultCalls int
+	results := []publishResultGetter{
+		fakePublishResult{id: "a"},
+		fakePublishResult{err: errors.New("boom")},
+		fakePublishResult{id: "c"},
+	}
+
+	_, failedIndex, err := gatherPublishResults(context.Background(), results, 2, func(i int, msgID string, err error) {
+		onResultCalls++
+	})
+
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if failedIndex != 1 {
+		t.Fatalf("failedIndex = %d, want 1", failedIndex)
+	}
+	if onResultCalls != len(results) {
+		t.Fatalf("onResult called %d times, want %d", onResultCalls, len(results))
+	}
+}
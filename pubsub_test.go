@@ -0,0 +1,44 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+type fakeReceiver struct {
+	ack map[string]bool
+	err error
+}
+
+func (f *fakeReceiver) Consume(ctx context.Context, messages []port.IPubSubMessage) (map[string]bool, error) {
+	return f.ack, f.err
+}
+
+func TestResolveAcksNacksOnReceiverError(t *testing.T) {
+	msg := &PubSubMessage{ID: "1"}
+	receivers := []port.PubSubReceiver{&fakeReceiver{err: errors.New("boom")}}
+
+	acked := resolveAcks(context.Background(), receivers, []port.IPubSubMessage{msg})
+
+	if acked["1"] {
+		t.Fatalf("expected message 1 to not be acked when the receiver errors")
+	}
+}
+
+func TestResolveAcksOnlyAcksWhatReceiversReportSuccessful(t *testing.T) {
+	msg1 := &PubSubMessage{ID: "1"}
+	msg2 := &PubSubMessage{ID: "2"}
+	receivers := []port.PubSubReceiver{&fakeReceiver{ack: map[string]bool{"1": true, "2": false}}}
+
+	acked := resolveAcks(context.Background(), receivers, []port.IPubSubMessage{msg1, msg2})
+
+	if !acked["1"] {
+		t.Fatalf("expected message 1 to be acked")
+	}
+	if acked["2"] {
+		t.Fatalf("expected message 2 to not be acked")
+	}
+}